@@ -0,0 +1,138 @@
+// Package geo collects the geo-coordinate math and distance-parsing
+// helpers used across geodisk's commands, along with a prefix-coded
+// spatial index (see index.go) for k-nearest-neighbor style queries
+// over large datasets.
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// EarthRadiusKM is the radius of the earth in kilometers, used as the
+// sphere radius for Haversin.
+const EarthRadiusKM = 6371
+
+// ErrInvalidDistance is returned by ParseDistance when the string does
+// not contain a valid number followed by a known unit suffix.
+var ErrInvalidDistance = errors.New("geo: invalid distance, expects a number followed by km, mi, m or nmi")
+
+// Unit represents a unit of distance.
+type Unit int
+
+// Supported distance units.
+const (
+	Kilometers Unit = iota
+	Miles
+	Meters
+	NauticalMiles
+)
+
+// String returns the suffix associated with a Unit.
+func (u Unit) String() string {
+	switch u {
+	case Miles:
+		return "mi"
+	case Meters:
+		return "m"
+	case NauticalMiles:
+		return "nmi"
+	default:
+		return "km"
+	}
+}
+
+// kmPerUnit converts a distance of 1 Unit into kilometers.
+func kmPerUnit(u Unit) float64 {
+	switch u {
+	case Miles:
+		return 1.60934
+	case Meters:
+		return 0.001
+	case NauticalMiles:
+		return 1.852
+	default:
+		return 1
+	}
+}
+
+// ToKilometers converts a distance value expressed in u into
+// kilometers.
+func ToKilometers(value float64, u Unit) float64 {
+	return value * kmPerUnit(u)
+}
+
+// ToRadians converts a degree value into radians.
+func ToRadians(deg float64) float64 {
+	return (deg * math.Pi) / 180
+}
+
+// Haversin calculates the great-circle distance in kilometers between
+// two points on the earth's surface using the haversine formula.
+// lat1, long1, lat2 and long2 must all be in radians.
+func Haversin(lat1, long1, lat2, long2 float64) float64 {
+	latDiff := lat2 - lat1
+	longDiff := long2 - long1
+	latDiffMid := latDiff / 2
+	longDiffMid := longDiff / 2
+
+	latMidSin := math.Sin(latDiffMid)
+	longMidSin := math.Sin(longDiffMid)
+
+	a := (latMidSin * latMidSin) +
+		(math.Cos(lat1)*math.Cos(lat2))*(longMidSin*longMidSin)
+
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+	return EarthRadiusKM * c
+}
+
+// ParseDistanceUnit parses a unit suffix ("km", "mi", "m" or "nmi")
+// into a Unit.
+func ParseDistanceUnit(suffix string) (Unit, error) {
+	switch strings.ToLower(suffix) {
+	case "km":
+		return Kilometers, nil
+	case "mi":
+		return Miles, nil
+	case "m":
+		return Meters, nil
+	case "nmi":
+		return NauticalMiles, nil
+	default:
+		return 0, fmt.Errorf("geo: unknown distance unit %q", suffix)
+	}
+}
+
+// ParseDistance parses a string like "10km", "3.5mi" or "500m" into a
+// value and its Unit. The suffix is the trailing run of characters
+// other than digits and '.', and must be one of km, mi, m or nmi; the
+// part before it is parsed with strconv.ParseFloat, so numbers that
+// end in a letter, such as "Inf" or "NaN", are not accepted.
+func ParseDistance(s string) (float64, Unit, error) {
+	s = strings.TrimSpace(s)
+
+	idx := len(s)
+	for idx > 0 && (s[idx-1] < '0' || s[idx-1] > '9') && s[idx-1] != '.' {
+		idx--
+	}
+
+	if idx == 0 || idx == len(s) {
+		return 0, 0, ErrInvalidDistance
+	}
+
+	value, err := strconv.ParseFloat(s[:idx], 64)
+	if err != nil {
+		return 0, 0, ErrInvalidDistance
+	}
+
+	unit, err := ParseDistanceUnit(s[idx:])
+	if err != nil {
+		return 0, 0, ErrInvalidDistance
+	}
+
+	return value, unit, nil
+}
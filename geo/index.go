@@ -0,0 +1,331 @@
+package geo
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// precisionStep is the number of bits trimmed off the full 64-bit
+// Morton hash for each successively coarser prefix stored alongside a
+// Point, mirroring the prefix-coded terms Lucene's (and bleve's) geo
+// point field generates at index time.
+const precisionStep = 9
+
+// totalBits is the number of bits used to encode each of latitude and
+// longitude before interleaving, giving a 64-bit hash in total.
+const totalBits = 32
+
+// Point is a single (lat, lon) coordinate paired with the caller's
+// identifier, as indexed by Index.
+type Point struct {
+	ID   string
+	Lat  float64
+	Long float64
+}
+
+// entry is a single prefix term pointing back at the Point it was
+// derived from.
+type entry struct {
+	hash uint64
+	pt   Point
+}
+
+// Index is a prefix-coded spatial index over a fixed set of Points. It
+// encodes each Point's (lat, lon) into a 64-bit Morton (Z-order) hash
+// and stores a truncated prefix of that hash at each precisionStep
+// interval, each kept in its own hash-sorted slice. BoundingBox picks
+// the finest prefix level that still covers the query box exactly
+// and range-scans just that level, instead of touching every Point.
+type Index struct {
+	points []Point
+
+	// levels lists every stored prefix length, from finest (64 bits)
+	// to coarsest, matching the keys of byLevel.
+	levels []uint
+
+	// byLevel holds, for each prefix length in levels, every Point's
+	// hash truncated to that length, sorted ascending for range scans.
+	byLevel map[uint][]entry
+}
+
+// NewIndex builds an Index over points.
+func NewIndex(points []Point) *Index {
+	idx := &Index{points: points, byLevel: make(map[uint][]entry)}
+
+	for bits := uint(totalBits * 2); bits > 0; bits -= precisionStep {
+		idx.levels = append(idx.levels, bits)
+		if bits < precisionStep {
+			break
+		}
+	}
+
+	for _, pt := range points {
+		hash := encode(pt.Lat, pt.Long)
+		for _, lvl := range idx.levels {
+			idx.byLevel[lvl] = append(idx.byLevel[lvl], entry{hash: truncate(hash, lvl), pt: pt})
+		}
+	}
+
+	for _, lvl := range idx.levels {
+		entries := idx.byLevel[lvl]
+		sort.Slice(entries, func(i, j int) bool { return entries[i].hash < entries[j].hash })
+	}
+
+	return idx
+}
+
+// quantize maps v (within [min, max]) onto a totalBits-wide unsigned
+// integer range, the same way a geohash implementation quantizes
+// latitude and longitude before interleaving their bits.
+func quantize(v, min, max float64) uint32 {
+	if v <= min {
+		return 0
+	}
+
+	span := max - min
+	scaled := (v - min) / span * float64(uint64(1)<<totalBits)
+
+	if scaled >= float64(uint64(1)<<totalBits) {
+		return math.MaxUint32
+	}
+
+	return uint32(scaled)
+}
+
+// interleave spreads the bits of x so that a matching interleave of y,
+// shifted left by one and OR'd in, produces the Morton (Z-order) code
+// of (x, y).
+func interleave(x uint32) uint64 {
+	v := uint64(x)
+	v = (v | (v << 16)) & 0x0000FFFF0000FFFF
+	v = (v | (v << 8)) & 0x00FF00FF00FF00FF
+	v = (v | (v << 4)) & 0x0F0F0F0F0F0F0F0F
+	v = (v | (v << 2)) & 0x3333333333333333
+	v = (v | (v << 1)) & 0x5555555555555555
+	return v
+}
+
+// encode computes the 64-bit Morton hash of a (lat, lon) pair. lon
+// must already be normalized into [-180, 180]; callers that might get
+// a value outside that range (e.g. from a radius added to a query
+// point) go through normalizeLon first.
+func encode(lat, lon float64) uint64 {
+	latBits := quantize(lat, -90, 90)
+	lonBits := quantize(lon, -180, 180)
+	return interleave(latBits) | (interleave(lonBits) << 1)
+}
+
+// truncate keeps the leading `bits` bits of hash and zeroes out every
+// bit below them, producing the coarser prefix stored for range scans.
+func truncate(hash uint64, bits uint) uint64 {
+	if bits >= 64 {
+		return hash
+	}
+	mask := ^uint64(0) << (64 - bits)
+	return hash & mask
+}
+
+// commonPrefixBits returns the number of leading bits a and b agree
+// on, i.e. the length of the longest shared Morton prefix.
+func commonPrefixBits(a, b uint64) uint {
+	return uint(bits.LeadingZeros64(a ^ b))
+}
+
+// precisionLevel picks the finest (most selective) stored level whose
+// truncated hash is still guaranteed to be the same for every value
+// between minHash and maxHash, so a single-value range scan at that
+// level exactly covers the query box with as few candidates as
+// possible. idx.levels is ordered finest-to-coarsest, so the first
+// level at or below the shared-prefix length is the one we want. It
+// returns false if even the coarsest level isn't safe (minHash and
+// maxHash disagree in their very first bit), in which case the caller
+// falls back to a full scan.
+func (idx *Index) precisionLevel(minHash, maxHash uint64) (uint, bool) {
+	shared := commonPrefixBits(minHash, maxHash)
+
+	var level uint
+	found := false
+	for _, lvl := range idx.levels {
+		if lvl <= shared {
+			level = lvl
+			found = true
+			break
+		}
+	}
+
+	return level, found
+}
+
+// normalizeLon wraps lon into [-180, 180], so a query box built from a
+// query point plus/minus a radius (which can stray past the
+// antimeridian) still encodes to a sane hash.
+func normalizeLon(lon float64) float64 {
+	for lon > 180 {
+		lon -= 360
+	}
+	for lon < -180 {
+		lon += 360
+	}
+	return lon
+}
+
+// lonRange is a closed [min, max] slice of longitude handed to a
+// single BoundingBox scan.
+type lonRange struct {
+	min, max float64
+}
+
+// splitLonRange normalizes [minLon, maxLon] into one or two lonRanges
+// that each stay within [-180, 180]. A box that crosses the
+// antimeridian (e.g. 179 to -179) is split into [179, 180] and
+// [-180, -179] so BoundingBox can scan each half separately instead of
+// silently missing whichever side of the date line its naive min/max
+// comparison put second.
+func splitLonRange(minLon, maxLon float64) []lonRange {
+	if maxLon-minLon >= 360 {
+		return []lonRange{{-180, 180}}
+	}
+
+	nMin := normalizeLon(minLon)
+	nMax := normalizeLon(maxLon)
+
+	if nMin <= nMax {
+		return []lonRange{{nMin, nMax}}
+	}
+
+	return []lonRange{{nMin, 180}, {-180, nMax}}
+}
+
+// BoundingBox returns every indexed Point whose (lat, lon) falls
+// within [minLat, maxLat] x [minLon, maxLon]. minLon/maxLon may cross
+// the antimeridian (e.g. minLon=179, maxLon=-179); the box is then
+// scanned as two ranges, one on each side of the date line.
+func (idx *Index) BoundingBox(minLat, minLon, maxLat, maxLon float64) []Point {
+	if maxLat < minLat {
+		minLat, maxLat = maxLat, minLat
+	}
+
+	seen := make(map[string]bool)
+	var matches []Point
+
+	for _, lr := range splitLonRange(minLon, maxLon) {
+		idx.boundingBoxRange(minLat, lr.min, maxLat, lr.max, seen, &matches)
+	}
+
+	return matches
+}
+
+// boundingBoxRange scans a single [minLat, maxLat] x [minLon, maxLon]
+// box, where minLon <= maxLon and neither crosses the antimeridian. It
+// picks the finest prefix level that exactly covers the box and
+// range-scans it, falling back to a full scan over every Point when
+// the box is too wide for any stored prefix to cover in one range.
+// Either way it filters out the Z-order curve's false positives with
+// an exact lat/lon check before adding a Point to matches.
+func (idx *Index) boundingBoxRange(minLat, minLon, maxLat, maxLon float64, seen map[string]bool, matches *[]Point) {
+	minHash := encode(minLat, minLon)
+	maxHash := encode(maxLat, maxLon)
+	if maxHash < minHash {
+		minHash, maxHash = maxHash, minHash
+	}
+
+	add := func(pt Point) {
+		if seen[pt.ID] {
+			return
+		}
+		if pt.Lat < minLat || pt.Lat > maxLat || pt.Long < minLon || pt.Long > maxLon {
+			return
+		}
+		seen[pt.ID] = true
+		*matches = append(*matches, pt)
+	}
+
+	level, ok := idx.precisionLevel(minHash, maxHash)
+	if !ok {
+		for _, pt := range idx.points {
+			add(pt)
+		}
+		return
+	}
+
+	target := truncate(minHash, level)
+	entries := idx.byLevel[level]
+
+	lo := sort.Search(len(entries), func(i int) bool { return entries[i].hash >= target })
+	hi := sort.Search(len(entries), func(i int) bool { return entries[i].hash > target })
+
+	for _, e := range entries[lo:hi] {
+		add(e.pt)
+	}
+}
+
+// kmPerDegreeLat is the approximate number of kilometers spanned by a
+// single degree of latitude, used to turn a search radius into a
+// bounding-box prefilter.
+const kmPerDegreeLat = 111.32
+
+// WithinDistance returns every indexed Point within dist kilometers of
+// (lat, lon). It prefilters candidates with BoundingBox, sized off a
+// degrees-per-km approximation, then refines with an exact haversine
+// check.
+func (idx *Index) WithinDistance(lat, lon, dist float64) []Point {
+	latDelta := dist / kmPerDegreeLat
+	lonDelta := dist / (kmPerDegreeLat * math.Max(math.Cos(ToRadians(lat)), 0.01))
+
+	candidates := idx.BoundingBox(lat-latDelta, lon-lonDelta, lat+latDelta, lon+lonDelta)
+
+	var matches []Point
+	for _, pt := range candidates {
+		d := Haversin(ToRadians(lat), ToRadians(lon), ToRadians(pt.Lat), ToRadians(pt.Long))
+		if d <= dist {
+			matches = append(matches, pt)
+		}
+	}
+
+	return matches
+}
+
+// NearestResult pairs a Point with its distance in kilometers from the
+// query coordinate used to find it.
+type NearestResult struct {
+	Point Point
+	Dist  float64
+}
+
+// Nearest returns the n closest indexed Points to (lat, lon), sorted
+// by ascending distance. It repeatedly widens a WithinDistance search
+// radius until it has gathered at least n candidates (or covered every
+// Point), so it only needs to haversine-rank a small candidate set
+// rather than the whole dataset.
+func (idx *Index) Nearest(lat, lon float64, n int) []NearestResult {
+	if n <= 0 || len(idx.points) == 0 {
+		return nil
+	}
+
+	radius := 10.0
+	maxRadius := EarthRadiusKM * math.Pi
+
+	var candidates []Point
+	for {
+		candidates = idx.WithinDistance(lat, lon, radius)
+		if len(candidates) >= n || radius >= maxRadius {
+			break
+		}
+		radius *= 2
+	}
+
+	results := make([]NearestResult, 0, len(candidates))
+	for _, pt := range candidates {
+		d := Haversin(ToRadians(lat), ToRadians(lon), ToRadians(pt.Lat), ToRadians(pt.Long))
+		results = append(results, NearestResult{Point: pt, Dist: d})
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Dist < results[j].Dist })
+
+	if len(results) > n {
+		results = results[:n]
+	}
+
+	return results
+}
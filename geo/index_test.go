@@ -0,0 +1,74 @@
+package geo
+
+import (
+	"testing"
+)
+
+func TestBoundingBox_CrossesAntimeridian(t *testing.T) {
+	idx := NewIndex([]Point{
+		{ID: "zero", Lat: 0, Long: 0},
+		{ID: "near", Lat: 0, Long: 179.99},
+		{ID: "far-side", Lat: 0, Long: -179.99},
+	})
+
+	matches := idx.BoundingBox(-1, 179, 1, -179)
+
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 points straddling the antimeridian, got %d: %+v", len(matches), matches)
+	}
+
+	byID := map[string]bool{}
+	for _, pt := range matches {
+		byID[pt.ID] = true
+	}
+	if !byID["near"] || !byID["far-side"] {
+		t.Fatalf("expected near and far-side, got %+v", matches)
+	}
+}
+
+func TestNearest_PrefersPointAcrossDateLineOverFarPoint(t *testing.T) {
+	idx := NewIndex([]Point{
+		{ID: "zero", Lat: 0, Long: 0},
+		{ID: "near", Lat: 0, Long: 179.99},
+		{ID: "far-side", Lat: 0, Long: -179.99},
+	})
+
+	results := idx.Nearest(0, 179.999, 3)
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+
+	if results[0].Point.ID != "near" {
+		t.Errorf("expected nearest to be %q, got %q", "near", results[0].Point.ID)
+	}
+	if results[1].Point.ID != "far-side" {
+		t.Errorf("expected second nearest to be %q (across the date line), got %q", "far-side", results[1].Point.ID)
+	}
+	if results[2].Point.ID != "zero" {
+		t.Errorf("expected farthest of the three to be %q, got %q", "zero", results[2].Point.ID)
+	}
+}
+
+func TestWithinDistance_FindsPointAcrossDateLine(t *testing.T) {
+	idx := NewIndex([]Point{
+		{ID: "zero", Lat: 0, Long: 0},
+		{ID: "far-side", Lat: 0, Long: -179.99},
+	})
+
+	matches := idx.WithinDistance(0, 179.999, 5)
+	if len(matches) != 1 || matches[0].ID != "far-side" {
+		t.Fatalf("expected only far-side within 5km across the date line, got %+v", matches)
+	}
+}
+
+func TestBoundingBox_FiltersOutOfRangePoints(t *testing.T) {
+	idx := NewIndex([]Point{
+		{ID: "in", Lat: 10, Long: 10},
+		{ID: "out", Lat: 50, Long: 50},
+	})
+
+	matches := idx.BoundingBox(0, 0, 20, 20)
+	if len(matches) != 1 || matches[0].ID != "in" {
+		t.Fatalf("expected only %q inside the box, got %+v", "in", matches)
+	}
+}
@@ -0,0 +1,75 @@
+package geodb
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// ErrInvalidCSVFormat is returned when a csv row does not have exactly
+// 3 fields.
+var ErrInvalidCSVFormat = errors.New("csv data has invalid format, expects 3 per line")
+
+// ErrInvalidGeoHeader is returned when a csv's header does not match
+// "id","lat","lng".
+var ErrInvalidGeoHeader = errors.New("csv has invalid geo header or has no header")
+
+// csvSource streams Records out of an "id","lat","lng" csv file, one
+// row at a time, the same way sqlSource streams rows out of a
+// database/sql result set.
+type csvSource struct {
+	reader *csv.Reader
+}
+
+// NewCSVSource validates target's "id","lat","lng" header and returns
+// a GeoSource streaming its remaining rows, so the csv and db
+// pipelines share the same ranking code instead of each parsing rows
+// on their own.
+func NewCSVSource(target io.Reader) (GeoSource, error) {
+	reader := csv.NewReader(target)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(header) != 3 {
+		return nil, ErrInvalidCSVFormat
+	}
+
+	if header[0] != "id" || header[1] != "lat" || header[2] != "lng" {
+		return nil, ErrInvalidGeoHeader
+	}
+
+	return &csvSource{reader: reader}, nil
+}
+
+// Next reads the next row into a Record. It returns io.EOF once the
+// csv is exhausted.
+func (s *csvSource) Next() (Record, error) {
+	line, err := s.reader.Read()
+	if err != nil {
+		return Record{}, err
+	}
+
+	if len(line) != 3 {
+		return Record{}, ErrInvalidCSVFormat
+	}
+
+	lat, err := strconv.ParseFloat(line[1], 64)
+	if err != nil {
+		return Record{}, err
+	}
+
+	lng, err := strconv.ParseFloat(line[2], 64)
+	if err != nil {
+		return Record{}, err
+	}
+
+	return Record{ID: line[0], Lat: lat, Long: lng}, nil
+}
+
+// Close is a no-op: csvSource does not own target.
+func (s *csvSource) Close() error { return nil }
@@ -0,0 +1,101 @@
+// Package geodb implements geo-coordinate sources that stream records
+// from external systems so the CLI's top-5/bottom-5 pipeline can run
+// over them the same way it runs over a CSV file. A source reads from
+// a Postgres/MySQL table, a MongoDB collection, or a MaxMind-style
+// MMDB IP-to-location database, all configured via a config.yaml file.
+package geodb
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ErrUnsupportedDriver is returned by New when the config.yaml driver
+// value does not match any known source.
+var ErrUnsupportedDriver = errors.New("geodb: unsupported driver")
+
+// Record represents a single geo-coordinate pulled from a GeoSource,
+// with latitude and longitude expressed in degrees, as read from the
+// underlying table, collection or file.
+type Record struct {
+	ID   string
+	Lat  float64
+	Long float64
+}
+
+// GeoSource defines a streaming source of geo-coordinate Records,
+// read one at a time the way NewCSVSource's source walks a CSV file
+// line by line. Next returns io.EOF once the source is exhausted.
+type GeoSource interface {
+	Next() (Record, error)
+	Close() error
+}
+
+// Columns maps the id/lat/lng fields of a Record onto the column or
+// field names used by the underlying table or collection. It is
+// unused by the mmdb driver, which reads raw IPs rather than mapped
+// columns; see newMMDBSource.
+type Columns struct {
+	ID  string `yaml:"id"`
+	Lat string `yaml:"lat"`
+	Lng string `yaml:"lng"`
+}
+
+// Config describes how to connect to and read from a geo database, as
+// loaded from a config.yaml file.
+type Config struct {
+	// Driver selects the GeoSource implementation: "postgres", "mysql",
+	// "mongo" or "mmdb".
+	Driver string `yaml:"driver"`
+
+	// DSN is the connection string for the sql and mongo drivers.
+	DSN string `yaml:"dsn"`
+
+	// Database is the database name used by the mongo driver.
+	Database string `yaml:"database"`
+
+	// Path is the MaxMind MMDB file used by the mmdb driver.
+	Path string `yaml:"path"`
+
+	// Table is the table or collection to read from for the sql and
+	// mongo drivers. For the mmdb driver, it is a newline-delimited
+	// file of IP addresses to resolve.
+	Table string `yaml:"table"`
+
+	// Columns maps Record fields onto source-specific names.
+	Columns Columns `yaml:"columns"`
+}
+
+// LoadConfig reads and parses a config.yaml file at the given path
+// into a Config.
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// New creates a GeoSource for the driver named within cfg. Supported
+// drivers are "postgres", "mysql", "mongo" and "mmdb".
+func New(cfg *Config) (GeoSource, error) {
+	switch cfg.Driver {
+	case "postgres", "mysql":
+		return newSQLSource(cfg)
+	case "mongo":
+		return newMongoSource(cfg)
+	case "mmdb":
+		return newMMDBSource(cfg)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedDriver, cfg.Driver)
+	}
+}
@@ -0,0 +1,72 @@
+package geodb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// mmdbSource resolves each IP address listed in cfg.Table to a
+// lat/lng pair using the MaxMind GeoLite2 database at cfg.Path, so IP
+// ranges can be ranked by proximity the same way coordinate records
+// are.
+type mmdbSource struct {
+	db   *geoip2.Reader
+	scan *bufio.Scanner
+	file *os.File
+}
+
+// newMMDBSource opens the MMDB file at cfg.Path and the newline
+// delimited IP list at cfg.Table.
+func newMMDBSource(cfg *Config) (GeoSource, error) {
+	db, err := geoip2.Open(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(cfg.Table)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &mmdbSource{db: db, scan: bufio.NewScanner(file), file: file}, nil
+}
+
+// Next reads the next IP address, resolves it to a city location and
+// returns it as a Record. It returns io.EOF once the IP list is
+// exhausted.
+func (m *mmdbSource) Next() (Record, error) {
+	if !m.scan.Scan() {
+		if err := m.scan.Err(); err != nil {
+			return Record{}, err
+		}
+		return Record{}, io.EOF
+	}
+
+	ip := net.ParseIP(m.scan.Text())
+	if ip == nil {
+		return Record{}, fmt.Errorf("geodb: invalid ip %q", m.scan.Text())
+	}
+
+	city, err := m.db.City(ip)
+	if err != nil {
+		return Record{}, err
+	}
+
+	return Record{
+		ID:   ip.String(),
+		Lat:  city.Location.Latitude,
+		Long: city.Location.Longitude,
+	}, nil
+}
+
+// Close releases the IP list file and the MMDB reader.
+func (m *mmdbSource) Close() error {
+	m.file.Close()
+	return m.db.Close()
+}
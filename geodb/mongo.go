@@ -0,0 +1,86 @@
+package geodb
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoSource streams Records from a MongoDB collection using a
+// single find cursor.
+type mongoSource struct {
+	client *mongo.Client
+	cursor *mongo.Cursor
+	ctx    context.Context
+	cols   Columns
+}
+
+// newMongoSource connects to cfg.DSN and opens a cursor over every
+// document in cfg.Database/cfg.Table.
+func newMongoSource(cfg *Config) (GeoSource, error) {
+	ctx := context.Background()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.DSN))
+	if err != nil {
+		return nil, err
+	}
+
+	collection := client.Database(cfg.Database).Collection(cfg.Table)
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		client.Disconnect(ctx)
+		return nil, err
+	}
+
+	return &mongoSource{client: client, cursor: cursor, ctx: ctx, cols: cfg.Columns}, nil
+}
+
+// Next decodes the next document in the cursor into a Record using
+// the field names in cols. It returns io.EOF once the cursor is
+// exhausted.
+func (m *mongoSource) Next() (Record, error) {
+	if !m.cursor.Next(m.ctx) {
+		if err := m.cursor.Err(); err != nil {
+			return Record{}, err
+		}
+		return Record{}, io.EOF
+	}
+
+	var doc bson.M
+	if err := m.cursor.Decode(&doc); err != nil {
+		return Record{}, err
+	}
+
+	return Record{
+		ID:   fmt.Sprint(doc[m.cols.ID]),
+		Lat:  toFloat64(doc[m.cols.Lat]),
+		Long: toFloat64(doc[m.cols.Lng]),
+	}, nil
+}
+
+// Close releases the cursor and disconnects the client.
+func (m *mongoSource) Close() error {
+	m.cursor.Close(m.ctx)
+	return m.client.Disconnect(m.ctx)
+}
+
+// toFloat64 coerces the numeric bson values mongo can hand back
+// (float64, int32, int64) into a float64, defaulting to 0 for
+// anything else.
+func toFloat64(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,60 @@
+package geodb
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+// sqlSource streams Records from a Postgres or MySQL table, one row
+// at a time, using database/sql.
+type sqlSource struct {
+	db   *sql.DB
+	rows *sql.Rows
+}
+
+// newSQLSource opens cfg.DSN with the driver named in cfg.Driver and
+// queries cfg.Table for the id/lat/lng columns named in cfg.Columns.
+func newSQLSource(cfg *Config) (GeoSource, error) {
+	db, err := sql.Open(cfg.Driver, cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT %s, %s, %s FROM %s", cfg.Columns.ID, cfg.Columns.Lat, cfg.Columns.Lng, cfg.Table)
+
+	rows, err := db.Query(query)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &sqlSource{db: db, rows: rows}, nil
+}
+
+// Next reads the next row of the result set into a Record. It returns
+// io.EOF once all rows have been consumed.
+func (s *sqlSource) Next() (Record, error) {
+	if !s.rows.Next() {
+		if err := s.rows.Err(); err != nil {
+			return Record{}, err
+		}
+		return Record{}, io.EOF
+	}
+
+	var rec Record
+	if err := s.rows.Scan(&rec.ID, &rec.Lat, &rec.Long); err != nil {
+		return Record{}, err
+	}
+
+	return rec, nil
+}
+
+// Close releases the result set and the underlying database handle.
+func (s *sqlSource) Close() error {
+	s.rows.Close()
+	return s.db.Close()
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/influx6/geodisk/geodb"
+)
+
+// Supported LoadGeoRecords input formats.
+const (
+	FormatCSV     = "csv"
+	FormatGeoJSON = "geojson"
+	FormatGeoURI  = "geouri"
+)
+
+// LoadGeoRecords reads GeoRecords out of target in the given format,
+// leaving Lat and Long in degrees and Dist unset; callers convert to
+// radians and compute distance against their own target coordinate
+// afterwards. Supported formats are FormatCSV (the "id","lat","lng"
+// csv this package has always read), FormatGeoJSON (a GeoJSON
+// FeatureCollection of Point features) and FormatGeoURI (newline
+// delimited RFC 5870 `geo:` URIs).
+func LoadGeoRecords(target io.Reader, format string) ([]GeoRecord, error) {
+	switch format {
+	case "", FormatCSV:
+		return loadCSVGeoRecords(target)
+	case FormatGeoJSON:
+		return loadGeoJSONRecords(target)
+	case FormatGeoURI:
+		return loadGeoURIRecords(target)
+	default:
+		return nil, fmt.Errorf("unsupported format %q, expects one of %q, %q or %q", format, FormatCSV, FormatGeoJSON, FormatGeoURI)
+	}
+}
+
+// loadCSVGeoRecords reads the "id","lat","lng" csv format documented
+// on GeoRecord, via the same geodb.GeoSource the db command streams
+// from, so the csv and db pipelines share one row parser.
+func loadCSVGeoRecords(target io.Reader) ([]GeoRecord, error) {
+	source, err := geodb.NewCSVSource(target)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+
+	var records []GeoRecord
+
+	for {
+		rec, err := source.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return records, err
+		}
+
+		records = append(records, GeoRecord{ID: rec.ID, Lat: rec.Lat, Long: rec.Long})
+	}
+
+	return records, nil
+}
+
+// geoJSONFeatureCollection mirrors the subset of the GeoJSON spec
+// LoadGeoRecords understands: a FeatureCollection of Point features.
+type geoJSONFeatureCollection struct {
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Properties geoJSONProperties `json:"properties"`
+	Geometry   geoJSONGeometry   `json:"geometry"`
+}
+
+type geoJSONProperties struct {
+	ID string `json:"id"`
+}
+
+type geoJSONGeometry struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// loadGeoJSONRecords reads a GeoJSON FeatureCollection, taking the ID
+// from each feature's `properties.id` and the coordinates from its
+// Point geometry. Per the GeoJSON spec, coordinates are ordered
+// [longitude, latitude].
+func loadGeoJSONRecords(target io.Reader) ([]GeoRecord, error) {
+	var collection geoJSONFeatureCollection
+	if err := json.NewDecoder(target).Decode(&collection); err != nil {
+		return nil, err
+	}
+
+	records := make([]GeoRecord, 0, len(collection.Features))
+
+	for _, feature := range collection.Features {
+		if feature.Geometry.Type != "Point" {
+			continue
+		}
+
+		var record GeoRecord
+		record.ID = feature.Properties.ID
+		record.Long = feature.Geometry.Coordinates[0]
+		record.Lat = feature.Geometry.Coordinates[1]
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// loadGeoURIRecords reads a newline-delimited list of RFC 5870 `geo:`
+// URIs, one GeoRecord per non-blank line.
+func loadGeoURIRecords(target io.Reader) ([]GeoRecord, error) {
+	scanner := bufio.NewScanner(target)
+
+	var records []GeoRecord
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		record, err := parseGeoURI(line)
+		if err != nil {
+			return records, err
+		}
+
+		records = append(records, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return records, err
+	}
+
+	return records, nil
+}
+
+// parseGeoURI parses a single RFC 5870 URI of the form
+// `geo:lat,lon[,alt];u=unc;param=val`. The uri is split on ":" then
+// ";"; the first two comma-separated components of the coordinate
+// part are taken as lat/lon (a third, optional, component is
+// altitude), and every remaining `;key=value` pair is stashed on the
+// returned GeoRecord's Params.
+func parseGeoURI(uri string) (GeoRecord, error) {
+	scheme, rest, ok := strings.Cut(uri, ":")
+	if !ok || scheme != "geo" {
+		return GeoRecord{}, fmt.Errorf("invalid geo uri, expects \"geo:\" scheme: %q", uri)
+	}
+
+	parts := strings.Split(rest, ";")
+
+	coords := strings.Split(parts[0], ",")
+	if len(coords) < 2 {
+		return GeoRecord{}, fmt.Errorf("invalid geo uri, expects lat,lon coordinates: %q", uri)
+	}
+
+	lat, err := strconv.ParseFloat(coords[0], 64)
+	if err != nil {
+		return GeoRecord{}, err
+	}
+
+	long, err := strconv.ParseFloat(coords[1], 64)
+	if err != nil {
+		return GeoRecord{}, err
+	}
+
+	var record GeoRecord
+	record.ID = fmt.Sprintf("%s,%s", coords[0], coords[1])
+	record.Lat = lat
+	record.Long = long
+
+	if len(parts) > 1 {
+		record.Params = make(map[string][]string, len(parts)-1)
+		for _, param := range parts[1:] {
+			key, value, _ := strings.Cut(param, "=")
+			record.Params[key] = append(record.Params[key], value)
+		}
+	}
+
+	return record, nil
+}
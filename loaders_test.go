@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadGeoJSONRecords(t *testing.T) {
+	fixture := `{
+		"type": "FeatureCollection",
+		"features": [
+			{"type": "Feature", "properties": {"id": "a"}, "geometry": {"type": "Point", "coordinates": [4.478617, 51.925146]}},
+			{"type": "Feature", "properties": {"id": "b"}, "geometry": {"type": "LineString"}, "geometry_ignored": true}
+		]
+	}`
+
+	records, err := loadGeoJSONRecords(strings.NewReader(fixture))
+	if err != nil {
+		t.Fatalf("loadGeoJSONRecords returned error: %v", err)
+	}
+
+	if len(records) != 1 {
+		t.Fatalf("expected the non-Point feature to be skipped, got %d records", len(records))
+	}
+
+	if records[0].ID != "a" {
+		t.Errorf("expected id %q, got %q", "a", records[0].ID)
+	}
+	if records[0].Long != 4.478617 || records[0].Lat != 51.925146 {
+		t.Errorf("expected lat/long to come from [lon, lat] coordinates, got lat=%v long=%v", records[0].Lat, records[0].Long)
+	}
+}
+
+func TestParseGeoURI(t *testing.T) {
+	record, err := parseGeoURI("geo:51.925146,4.478617;u=35;crs=wgs84")
+	if err != nil {
+		t.Fatalf("parseGeoURI returned error: %v", err)
+	}
+
+	if record.Lat != 51.925146 || record.Long != 4.478617 {
+		t.Errorf("expected lat=51.925146 long=4.478617, got lat=%v long=%v", record.Lat, record.Long)
+	}
+
+	if got := record.Params["u"]; len(got) != 1 || got[0] != "35" {
+		t.Errorf("expected Params[u]=[35], got %v", got)
+	}
+	if got := record.Params["crs"]; len(got) != 1 || got[0] != "wgs84" {
+		t.Errorf("expected Params[crs]=[wgs84], got %v", got)
+	}
+}
+
+func TestParseGeoURI_InvalidScheme(t *testing.T) {
+	if _, err := parseGeoURI("http:51.9,4.4"); err == nil {
+		t.Fatal("expected an error for a non-geo scheme, got nil")
+	}
+}
+
+func TestParseGeoURI_MissingCoordinate(t *testing.T) {
+	if _, err := parseGeoURI("geo:51.925146"); err == nil {
+		t.Fatal("expected an error for a uri missing its longitude, got nil")
+	}
+}
+
+func TestLoadGeoURIRecords(t *testing.T) {
+	fixture := "geo:51.925146,4.478617\n\ngeo:40.714268,-74.005974;u=10\n"
+
+	records, err := loadGeoURIRecords(strings.NewReader(fixture))
+	if err != nil {
+		t.Fatalf("loadGeoURIRecords returned error: %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("expected blank lines to be skipped leaving 2 records, got %d", len(records))
+	}
+	if records[1].Params["u"][0] != "10" {
+		t.Errorf("expected second record's u param to be 10, got %v", records[1].Params["u"])
+	}
+}
@@ -2,35 +2,36 @@ package main
 
 import (
 	"bufio"
-	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
-	"math"
 	"os"
 	"path/filepath"
-	"strconv"
+	"runtime"
 
 	"sort"
 
 	"github.com/influx6/faux/flags"
+	"github.com/influx6/geodisk/geo"
+	"github.com/influx6/geodisk/geodb"
 )
 
 const (
-	earthRadius                 = 6371 // in kilometers
 	housingAnywhereGeoLatitude  = 51.925146
 	housingAnywhereGeoLongitude = 4.478617
 )
 
 var (
-	housingAnywhereGeoLongitudeRadians = toRadians(4.478617)
-	housingAnywhereGeoLatitudeRadians  = toRadians(51.925146)
+	housingAnywhereGeoLongitudeRadians = geo.ToRadians(4.478617)
+	housingAnywhereGeoLatitudeRadians  = geo.ToRadians(51.925146)
 )
 
-// errors ...
+// errors ... csv parsing itself now lives in geodb.NewCSVSource, shared
+// by geoDistanceWithCSV, LoadGeoRecords and TopN; these aliases keep
+// the errors available under their old names in this package.
 var (
-	ErrInvalidCSVFormat = errors.New("csv data has invalid format, expects 3 per line")
-	ErrInvalidGeoHeader = errors.New("csv has invalid geo header or has no header")
+	ErrInvalidCSVFormat = geodb.ErrInvalidCSVFormat
+	ErrInvalidGeoHeader = geodb.ErrInvalidGeoHeader
 )
 
 //**************************************************************
@@ -58,65 +59,64 @@ func (g GeoRecords) Less(i, j int) bool {
 	return g[i].Dist < g[j].Dist
 }
 
-// GeoRecord embodies data stored in expected csv where it contains
-// data in format of `"id","lat","long"`. Where the ID represents the giving
-// associated ID of geographical location with respective geographical
-// coordinates.
+// GeoRecord embodies a single geographical coordinate loaded by
+// LoadGeoRecords, whether it came from the csv, geojson or geouri
+// format. Lat and Long are in degrees until converted to radians for
+// distance calculation. Params holds any `;key=value` pairs trailing a
+// geo: URI record; it is nil for the csv and geojson formats.
 type GeoRecord struct {
-	ID   string
-	Lat  float64
-	Long float64
-	Dist float64
+	ID     string
+	Lat    float64
+	Long   float64
+	Dist   float64
+	Params map[string][]string
 }
 
-// distanceWithCSVFile attempts to load csv file from provided target path
-// calculating distance of each record from giving geo-coordinates of
-// latitude and longitude pairs (which must be in radians).
-func distanceWithCSVFile(target string, targetLat float64, targetLong float64) ([]GeoRecord, error) {
-	targetFile, err := os.Open(target)
-	if err != nil {
-		return nil, err
-	}
-
-	defer targetFile.Close()
+//**************************************************************
+// CLI methods
+//**************************************************************
 
-	return distanceWithCSVReader(bufio.NewReader(targetFile), targetLat, targetLong)
-}
+// geoDistanceWithDB loads the database described by the `config.yaml`
+// file named through the "config" flag, streams every record it holds
+// through a geodb.GeoSource (Postgres, MySQL, Mongo or a MaxMind MMDB
+// IP-to-location database) and runs the same top-5/bottom-5 ranking
+// pipeline geoDistanceWithCSV uses for its geojson and geouri formats.
+func geoDistanceWithDB(ctx flags.Context) error {
+	configFile := ctx.GetString("config")
+	if configFile == "" {
+		configFile = "config.yaml"
+	}
 
-// distanceWithCSVReader attempts to load csv data from provided io.Reader,
-// calculating distance of each record from giving geo-coordinates of
-// latitude and longitude pairs (which must be in radians).
-//
-// If CSV file is read and headers are validated to be correct, then
-// code moves to read file line by line, it expects each line to have a
-// maximum of 3 items, which it then converts into GeoRecord struct.
-// If any line contains more than wanted max length or if a lines latitdude
-// or longitude is not a valid float64 number, then it stops
-// returning all collected records and error.
-func distanceWithCSVReader(target io.Reader, targetLat float64, targetLong float64) ([]GeoRecord, error) {
-	csvReader := csv.NewReader(target)
-
-	// Read header of csv, if things fail, then return error
-	// validate header matches "
-	header, err := csvReader.Read()
+	cfg, err := geodb.LoadConfig(configFile)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// if headers are not 3 in total, then return format error.
-	if len(header) != 3 {
-		return nil, ErrInvalidCSVFormat
+	source, err := geodb.New(cfg)
+	if err != nil {
+		return err
 	}
+	defer source.Close()
 
-	// if headers don't match expected, then return invalid header error.
-	if header[0] != "id" || header[1] != "lat" || header[2] != "lng" {
-		return nil, ErrInvalidGeoHeader
+	records, err := distanceWithGeoSource(source, housingAnywhereGeoLatitudeRadians, housingAnywhereGeoLongitudeRadians)
+	if err != nil {
+		return err
 	}
 
-	var records []GeoRecord
+	sort.Sort(records)
+	printTop5Bottom5(records)
+	return nil
+}
+
+// distanceWithGeoSource drains a geodb.GeoSource, converting each
+// Record (in degrees) into a GeoRecord (in radians) and calculating
+// its distance from the target latitude and longitude, which must
+// also be in radians.
+func distanceWithGeoSource(source geodb.GeoSource, targetLat, targetLong float64) (GeoRecords, error) {
+	var records GeoRecords
 
 	for {
-		line, err := csvReader.Read()
+		rec, err := source.Next()
 		if err != nil {
 			if err == io.EOF {
 				break
@@ -124,29 +124,11 @@ func distanceWithCSVReader(target io.Reader, targetLat float64, targetLong float
 			return records, err
 		}
 
-		if len(line) != 3 {
-			return records, ErrInvalidCSVFormat
-		}
-
-		// parse latitude value which are expected to
-		// be in degrees to radians.
-		lat, err := strconv.ParseFloat(line[1], 64)
-		if err != nil {
-			return records, err
-		}
-
-		// parse longitude value which are expected to
-		// be in degrees to radians.
-		long, err := strconv.ParseFloat(line[2], 64)
-		if err != nil {
-			return records, err
-		}
-
 		var record GeoRecord
-		record.ID = line[0]
-		record.Lat = toRadians(lat)
-		record.Long = toRadians(long)
-		record.Dist = greatCircleDistance(record.Lat, record.Long, targetLat, targetLong)
+		record.ID = rec.ID
+		record.Lat = geo.ToRadians(rec.Lat)
+		record.Long = geo.ToRadians(rec.Long)
+		record.Dist = geo.Haversin(record.Lat, record.Long, targetLat, targetLong)
 
 		records = append(records, record)
 	}
@@ -154,40 +136,15 @@ func distanceWithCSVReader(target io.Reader, targetLat float64, targetLong float
 	return records, nil
 }
 
-// greatCircleDistance calculates the great-circle distance over a spherical domain (eg earth)
-// for the distance between two points on the sphere. It uses the haversine method.
-func greatCircleDistance(lat1, long1, lat2, long2 float64) float64 {
-	latDiff := lat2 - lat1
-	longDiff := long2 - long1
-	latDiffMid := latDiff / 2
-	longDiffMid := longDiff / 2
-
-	latMidSin := math.Sin(latDiffMid)
-	longMidSin := math.Sin(longDiffMid)
-
-	a := (latMidSin * latMidSin) +
-		(math.Cos(lat1)*math.Cos(lat2))*(longMidSin*longMidSin)
-
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-
-	return earthRadius * c
-}
-
-func toRadians(t float64) float64 {
-	return (t * math.Pi) / 180
-}
-
-//**************************************************************
-// CLI methods
-//**************************************************************
-
-func geoDistanceWithDB(ctx flags.Context) error {
-	fmt.Fprintln(os.Stderr, "DB command not available yet.")
-	return nil
-}
-
+// geoDistanceWithCSV loads the dataset named through the "file" flag
+// (or the first positional argument) in the format named through the
+// "format" flag (csv, geojson or geouri; csv by default) and ranks it
+// against Housing Anywhere's coordinates. The csv format is streamed
+// through TopN, keeping the "topN" nearest and farthest records; the
+// geojson and geouri formats are loaded in full via LoadGeoRecords and
+// ranked with the same top-5/bottom-5 pipeline as geoDistanceWithDB.
 func geoDistanceWithCSV(ctx flags.Context) error {
-	csvFile, _ := ctx.GetString("file")
+	csvFile := ctx.GetString("file")
 	if csvFile == "" {
 		// if arguments is not empty, then take first value has file name
 		// else return error.
@@ -199,14 +156,65 @@ func geoDistanceWithCSV(ctx flags.Context) error {
 		csvFile = args[0]
 	}
 
+	format := ctx.GetString("format")
+	if format == "" {
+		format = FormatCSV
+	}
+
 	csvFile = filepath.Clean(csvFile)
-	records, err := distanceWithCSVFile(csvFile, housingAnywhereGeoLatitudeRadians, housingAnywhereGeoLongitudeRadians)
+
+	targetFile, err := os.Open(csvFile)
+	if err != nil {
+		return err
+	}
+	defer targetFile.Close()
+
+	// The csv format alone streams, so multi-GB files never need to
+	// hold more than O(topN) records in memory; geojson and geouri
+	// datasets are loaded in full via LoadGeoRecords.
+	if format == FormatCSV {
+		topN := ctx.GetInt("topN")
+		if topN <= 0 {
+			topN = 5
+		}
+
+		workers := ctx.GetInt("workers")
+		if workers <= 0 {
+			workers = runtime.NumCPU()
+		}
+
+		nearest, farthest, err := TopN(bufio.NewReader(targetFile), housingAnywhereGeoLatitudeRadians, housingAnywhereGeoLongitudeRadians, topN, workers)
+		if err != nil {
+			return err
+		}
+
+		printRanked(nearest, farthest)
+		return nil
+	}
+
+	rawRecords, err := LoadGeoRecords(bufio.NewReader(targetFile), format)
 	if err != nil {
 		return err
 	}
 
-	sort.Sort(GeoRecords(records))
+	records := make(GeoRecords, len(rawRecords))
+	for i, rec := range rawRecords {
+		rec.Lat = geo.ToRadians(rec.Lat)
+		rec.Long = geo.ToRadians(rec.Long)
+		rec.Dist = geo.Haversin(rec.Lat, rec.Long, housingAnywhereGeoLatitudeRadians, housingAnywhereGeoLongitudeRadians)
+		records[i] = rec
+	}
+
+	sort.Sort(records)
+	printTop5Bottom5(records)
+	return nil
+}
 
+// printTop5Bottom5 prints the 5 closest and 5 farthest records (by
+// Dist) from a sorted slice of GeoRecords to stdout, both lists
+// closest-first. It is used by geoDistanceWithDB and by
+// geoDistanceWithCSV's geojson and geouri formats.
+func printTop5Bottom5(records []GeoRecord) {
 	var top5, bottom5 []GeoRecord
 
 	recLen := len(records)
@@ -220,19 +228,28 @@ func geoDistanceWithCSV(ctx flags.Context) error {
 		bottom5 = records[recLen-5:]
 	}
 
-	fmt.Fprintln(os.Stdout, "Top 5 Locations closest to Housing Anywhere:")
-	for _, rec := range top5 {
+	printRanked(top5, bottom5)
+}
+
+// printRanked prints nearest and farthest to stdout in the order
+// given: TopN hands it farthest sorted farthest-first, while
+// printTop5Bottom5 hands it the tail of an ascending sort. Unlike
+// printTop5Bottom5, it makes no assumption about how many records each
+// slice holds, so it can print the bounded-heap results TopN returns
+// for an arbitrary -topN size.
+func printRanked(nearest, farthest []GeoRecord) {
+	fmt.Fprintf(os.Stdout, "Top %d Locations closest to Housing Anywhere:\n", len(nearest))
+	for _, rec := range nearest {
 		fmt.Fprintf(os.Stdout, "\tLocationID: %s (%.6f kilometers)\n", rec.ID, rec.Dist)
 	}
 
 	fmt.Println("")
-	fmt.Fprintln(os.Stdout, "Top 5 Locations farthest to Housing Anywhere:")
-	for _, rec := range bottom5 {
+	fmt.Fprintf(os.Stdout, "Top %d Locations farthest to Housing Anywhere:\n", len(farthest))
+	for _, rec := range farthest {
 		fmt.Fprintf(os.Stdout, "\tLocationID: %s (%.6f kilometers)\n", rec.ID, rec.Dist)
 	}
 
 	fmt.Println("")
-	return nil
 }
 
 func main() {
@@ -249,6 +266,21 @@ func main() {
 					Desc:    "csvfile to be used for calculation",
 					Default: "",
 				},
+				&flags.StringFlag{
+					Name:    "format",
+					Desc:    "input format: csv, geojson or geouri",
+					Default: FormatCSV,
+				},
+				&flags.IntFlag{
+					Name:    "topN",
+					Desc:    "number of closest/farthest records to keep; only used for the streamed csv format",
+					Default: 5,
+				},
+				&flags.IntFlag{
+					Name:    "workers",
+					Desc:    "number of goroutines computing haversine distance concurrently; only used for the streamed csv format",
+					Default: runtime.NumCPU(),
+				},
 			},
 		},
 		flags.Command{
@@ -263,5 +295,49 @@ func main() {
 					Default: "config.yaml",
 				},
 			},
+		},
+		flags.Command{
+			Name:      "serve",
+			ShortDesc: "Serve nearest/bounding-box/distance queries as a JSON HTTP API.",
+			Action:    geoDistanceServe,
+			Desc:      "Loads a dataset (csv/geojson/geouri file, or database via -config) once into a spatial index, then serves GET /ping, /nearest, /within and /bbox as JSON over HTTP.",
+			Usages:    []string{"geodisk -serve.file=./static/geoData.csv serve", "geodisk -serve.config=config.yaml serve"},
+			Flags: []flags.Flag{
+				&flags.StringFlag{
+					Name:    "file",
+					Desc:    "csv/geojson/geouri file to be used for the spatial index",
+					Default: "",
+				},
+				&flags.StringFlag{
+					Name:    "format",
+					Desc:    "input format of -file: csv, geojson or geouri",
+					Default: FormatCSV,
+				},
+				&flags.StringFlag{
+					Name:    "config",
+					Desc:    "config.yaml file describing a database to read the spatial index from, instead of -file",
+					Default: "",
+				},
+				&flags.StringFlag{
+					Name:    "unit",
+					Desc:    "default distance unit for responses: km, mi, m or nmi",
+					Default: "km",
+				},
+				&flags.StringFlag{
+					Name:    "http.addr",
+					Desc:    "address to listen for HTTP requests on",
+					Default: ":8080",
+				},
+				&flags.StringFlag{
+					Name:    "http.tls.cert",
+					Desc:    "TLS certificate file; serves HTTPS when set alongside -serve.http.tls.key",
+					Default: "",
+				},
+				&flags.StringFlag{
+					Name:    "http.tls.key",
+					Desc:    "TLS key file; serves HTTPS when set alongside -serve.http.tls.cert",
+					Default: "",
+				},
+			},
 		})
 }
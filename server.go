@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influx6/faux/flags"
+	"github.com/influx6/geodisk/geo"
+	"github.com/influx6/geodisk/geodb"
+)
+
+// apiRecord is the JSON shape returned by every serve endpoint.
+type apiRecord struct {
+	ID       string  `json:"id"`
+	Lat      float64 `json:"lat"`
+	Lng      float64 `json:"lng"`
+	Distance float64 `json:"distance"`
+	Unit     string  `json:"unit"`
+}
+
+// geoDistanceServe loads the dataset named through the "file"/"format"
+// or "config" flags once into a geo.Index, then serves that index over
+// HTTP so /nearest, /within and /bbox can answer queries in
+// sub-linear time instead of re-reading and re-sorting the dataset on
+// every request.
+func geoDistanceServe(ctx flags.Context) error {
+	index, err := buildServeIndex(ctx)
+	if err != nil {
+		return err
+	}
+
+	defaultUnit := ctx.GetString("unit")
+	unit, err := geo.ParseDistanceUnit(defaultUnit)
+	if err != nil {
+		return err
+	}
+
+	addr := ctx.GetString("http.addr")
+	certFile := ctx.GetString("http.tls.cert")
+	keyFile := ctx.GetString("http.tls.key")
+
+	router := chi.NewRouter()
+	router.Use(middleware.Logger)
+	router.Use(middleware.Recoverer)
+
+	router.Get("/ping", pingHandler)
+	router.Get("/nearest", nearestHandler(index, unit))
+	router.Get("/within", withinHandler(index, unit))
+	router.Get("/bbox", bboxHandler(index, unit))
+
+	fmt.Fprintf(os.Stdout, "geodisk: serving on %s\n", addr)
+
+	if certFile != "" && keyFile != "" {
+		return http.ListenAndServeTLS(addr, certFile, keyFile, router)
+	}
+
+	return http.ListenAndServe(addr, router)
+}
+
+// buildServeIndex loads the dataset named through the serve command's
+// flags (a "config" flag selects a geodb.GeoSource, otherwise "file"
+// and "format" select a LoadGeoRecords input) and builds a geo.Index
+// over it.
+func buildServeIndex(ctx flags.Context) (*geo.Index, error) {
+	configFile := ctx.GetString("config")
+	if configFile != "" {
+		cfg, err := geodb.LoadConfig(configFile)
+		if err != nil {
+			return nil, err
+		}
+
+		source, err := geodb.New(cfg)
+		if err != nil {
+			return nil, err
+		}
+		defer source.Close()
+
+		var points []geo.Point
+		for {
+			rec, err := source.Next()
+			if err != nil {
+				if err == io.EOF {
+					break
+				}
+				return nil, err
+			}
+
+			points = append(points, geo.Point{ID: rec.ID, Lat: rec.Lat, Long: rec.Long})
+		}
+
+		return geo.NewIndex(points), nil
+	}
+
+	dataFile := ctx.GetString("file")
+	if dataFile == "" {
+		return nil, errors.New("require either -config or -file, see geodisk serve help")
+	}
+
+	format := ctx.GetString("format")
+	if format == "" {
+		format = FormatCSV
+	}
+
+	targetFile, err := os.Open(dataFile)
+	if err != nil {
+		return nil, err
+	}
+	defer targetFile.Close()
+
+	records, err := LoadGeoRecords(bufio.NewReader(targetFile), format)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]geo.Point, len(records))
+	for i, rec := range records {
+		points[i] = geo.Point{ID: rec.ID, Lat: rec.Lat, Long: rec.Long}
+	}
+
+	return geo.NewIndex(points), nil
+}
+
+// pingHandler answers a liveness check.
+func pingHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("pong"))
+}
+
+// nearestHandler serves GET /nearest?lat=&lng=&n=5&unit=km, returning
+// the n closest indexed points to (lat, lng).
+func nearestHandler(index *geo.Index, defaultUnit geo.Unit) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lat, lng, err := queryLatLng(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		n := 5
+		if raw := r.URL.Query().Get("n"); raw != "" {
+			n, err = strconv.Atoi(raw)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+		}
+
+		unit := defaultUnit
+		if raw := r.URL.Query().Get("unit"); raw != "" {
+			unit, err = geo.ParseDistanceUnit(raw)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+		}
+
+		results := index.Nearest(lat, lng, n)
+
+		out := make([]apiRecord, len(results))
+		for i, res := range results {
+			out[i] = apiRecord{
+				ID:       res.Point.ID,
+				Lat:      res.Point.Lat,
+				Lng:      res.Point.Long,
+				Distance: res.Dist / geo.ToKilometers(1, unit),
+				Unit:     unit.String(),
+			}
+		}
+
+		writeJSON(w, out)
+	}
+}
+
+// withinHandler serves GET /within?lat=&lng=&distance=10km, returning
+// every indexed point within the parsed distance.
+func withinHandler(index *geo.Index, defaultUnit geo.Unit) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lat, lng, err := queryLatLng(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		raw := r.URL.Query().Get("distance")
+		if raw == "" {
+			writeError(w, errors.New("missing required \"distance\" query parameter"))
+			return
+		}
+
+		value, unit, err := geo.ParseDistance(raw)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		distKM := geo.ToKilometers(value, unit)
+		points := index.WithinDistance(lat, lng, distKM)
+
+		out := make([]apiRecord, len(points))
+		for i, pt := range points {
+			d := geo.Haversin(geo.ToRadians(lat), geo.ToRadians(lng), geo.ToRadians(pt.Lat), geo.ToRadians(pt.Long))
+			out[i] = apiRecord{ID: pt.ID, Lat: pt.Lat, Lng: pt.Long, Distance: d / geo.ToKilometers(1, unit), Unit: unit.String()}
+		}
+
+		writeJSON(w, out)
+	}
+}
+
+// bboxHandler serves GET /bbox?minLat=&minLon=&maxLat=&maxLon=,
+// returning every indexed point within the box.
+func bboxHandler(index *geo.Index, defaultUnit geo.Unit) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		minLat, err := queryFloat(r, "minLat")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		minLon, err := queryFloat(r, "minLon")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		maxLat, err := queryFloat(r, "maxLat")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		maxLon, err := queryFloat(r, "maxLon")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		points := index.BoundingBox(minLat, minLon, maxLat, maxLon)
+
+		out := make([]apiRecord, len(points))
+		for i, pt := range points {
+			out[i] = apiRecord{ID: pt.ID, Lat: pt.Lat, Lng: pt.Long, Unit: defaultUnit.String()}
+		}
+
+		writeJSON(w, out)
+	}
+}
+
+// queryLatLng reads the required "lat" and "lng" query parameters.
+func queryLatLng(r *http.Request) (float64, float64, error) {
+	lat, err := queryFloat(r, "lat")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	lng, err := queryFloat(r, "lng")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return lat, lng, nil
+}
+
+// queryFloat reads and parses a required float64 query parameter.
+func queryFloat(r *http.Request, name string) (float64, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return 0, fmt.Errorf("missing required %q query parameter", name)
+	}
+
+	return strconv.ParseFloat(raw, 64)
+}
+
+// writeJSON writes v to w as a JSON response body.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes err as a 400 JSON error response.
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influx6/geodisk/geo"
+)
+
+func testIndex() *geo.Index {
+	return geo.NewIndex([]geo.Point{
+		{ID: "a", Lat: 0, Long: 0},
+		{ID: "b", Lat: 0.01, Long: 0.01},
+		{ID: "c", Lat: 0.02, Long: 0.02},
+		{ID: "d", Lat: 0.03, Long: 0.03},
+		{ID: "e", Lat: 0.04, Long: 0.04},
+		{ID: "f", Lat: 0.05, Long: 0.05},
+		{ID: "near", Lat: 0, Long: 179.99},
+		{ID: "far-side", Lat: 0, Long: -179.99},
+	})
+}
+
+func TestNearestHandler_DefaultsNAndUnit(t *testing.T) {
+	handler := nearestHandler(testIndex(), geo.Kilometers)
+
+	req := httptest.NewRequest(http.MethodGet, "/nearest?lat=0&lng=0", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var out []apiRecord
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if len(out) != 5 {
+		t.Fatalf("expected the default n=5, got %d results", len(out))
+	}
+	for _, rec := range out {
+		if rec.Unit != "km" {
+			t.Errorf("expected the default unit km, got %q", rec.Unit)
+		}
+	}
+}
+
+func TestWithinHandler_MissingDistance(t *testing.T) {
+	handler := withinHandler(testIndex(), geo.Kilometers)
+
+	req := httptest.NewRequest(http.MethodGet, "/within?lat=0&lng=0", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	assertBadRequestJSON(t, rec, "distance")
+}
+
+func TestBboxHandler_AntimeridianCrossing(t *testing.T) {
+	handler := bboxHandler(testIndex(), geo.Kilometers)
+
+	req := httptest.NewRequest(http.MethodGet, "/bbox?minLat=-1&minLon=179&maxLat=1&maxLon=-179", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var out []apiRecord
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	byID := map[string]bool{}
+	for _, rec := range out {
+		byID[rec.ID] = true
+	}
+	if !byID["near"] || !byID["far-side"] {
+		t.Fatalf("expected near and far-side to straddle the antimeridian box, got %+v", out)
+	}
+}
+
+func TestNearestHandler_InvalidLatReturnsBadRequestJSON(t *testing.T) {
+	handler := nearestHandler(testIndex(), geo.Kilometers)
+
+	req := httptest.NewRequest(http.MethodGet, "/nearest?lat=notanumber&lng=0", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	assertBadRequestJSON(t, rec, "notanumber")
+}
+
+// assertBadRequestJSON asserts rec holds the writeError shape: a 400
+// status, a JSON content type and a body of {"error": "<message
+// containing wantSubstr>"}.
+func assertBadRequestJSON(t *testing.T, rec *httptest.ResponseRecorder, wantSubstr string) {
+	t.Helper()
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected application/json content type, got %q", ct)
+	}
+
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode error response: %v", err)
+	}
+
+	msg, ok := body["error"]
+	if !ok {
+		t.Fatalf("expected an \"error\" key in the response body, got %+v", body)
+	}
+	if !strings.Contains(msg, wantSubstr) {
+		t.Errorf("expected error message to mention %q, got %q", wantSubstr, msg)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"container/heap"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/influx6/geodisk/geo"
+	"github.com/influx6/geodisk/geodb"
+)
+
+// TopN streams csv rows out of target, computing each row's distance
+// from (targetLat, targetLong) (both in radians) across workers
+// goroutines, and returns the k nearest and k farthest records without
+// ever holding more than O(k) records in memory. This replaces
+// accumulating every record into a single slice before sorting, which
+// is what makes a multi-GB csv input workable.
+func TopN(target io.Reader, targetLat, targetLong float64, k, workers int) (nearest GeoRecords, farthest GeoRecords, err error) {
+	if k <= 0 {
+		k = 5
+	}
+
+	if workers <= 0 {
+		workers = 1
+	}
+
+	source, err := geodb.NewCSVSource(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer source.Close()
+
+	rows := make(chan geodb.Record, workers*4)
+	computed := make(chan GeoRecord, workers*4)
+
+	var readErr error
+
+	go func() {
+		defer close(rows)
+
+		for {
+			rec, rerr := source.Next()
+			if rerr != nil {
+				if rerr != io.EOF {
+					readErr = rerr
+				}
+				return
+			}
+
+			rows <- rec
+		}
+	}()
+
+	var workersWG sync.WaitGroup
+	workersWG.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer workersWG.Done()
+
+			for row := range rows {
+				var record GeoRecord
+				record.ID = row.ID
+				record.Lat = geo.ToRadians(row.Lat)
+				record.Long = geo.ToRadians(row.Long)
+				record.Dist = geo.Haversin(record.Lat, record.Long, targetLat, targetLong)
+
+				computed <- record
+			}
+		}()
+	}
+
+	go func() {
+		workersWG.Wait()
+		close(computed)
+	}()
+
+	nearestHeap := &nearestMaxHeap{}
+	farthestHeap := &farthestMinHeap{}
+	heap.Init(nearestHeap)
+	heap.Init(farthestHeap)
+
+	for record := range computed {
+		pushNearest(nearestHeap, record, k)
+		pushFarthest(farthestHeap, record, k)
+	}
+
+	if readErr != nil {
+		return nil, nil, readErr
+	}
+
+	nearest = GeoRecords(*nearestHeap)
+	sort.Sort(nearest)
+
+	farthest = GeoRecords(*farthestHeap)
+	sort.Sort(sort.Reverse(farthest))
+
+	return nearest, farthest, nil
+}
+
+// nearestMaxHeap is a bounded max-heap (by Dist) used to keep the k
+// smallest-distance records seen so far: the root is always the
+// current worst of the k nearest, so it can be evicted in O(log k)
+// when a closer record arrives.
+type nearestMaxHeap GeoRecords
+
+func (h nearestMaxHeap) Len() int            { return len(h) }
+func (h nearestMaxHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h nearestMaxHeap) Less(i, j int) bool  { return h[i].Dist > h[j].Dist }
+func (h *nearestMaxHeap) Push(x interface{}) { *h = append(*h, x.(GeoRecord)) }
+func (h *nearestMaxHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// pushNearest adds record to h if h has fewer than k entries, or if
+// record is closer than h's current worst (root) entry.
+func pushNearest(h *nearestMaxHeap, record GeoRecord, k int) {
+	if h.Len() < k {
+		heap.Push(h, record)
+		return
+	}
+
+	if record.Dist < (*h)[0].Dist {
+		heap.Pop(h)
+		heap.Push(h, record)
+	}
+}
+
+// farthestMinHeap is a bounded min-heap (by Dist) used to keep the k
+// largest-distance records seen so far: the root is always the
+// current worst of the k farthest, so it can be evicted in O(log k)
+// when a farther record arrives.
+type farthestMinHeap GeoRecords
+
+func (h farthestMinHeap) Len() int            { return len(h) }
+func (h farthestMinHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h farthestMinHeap) Less(i, j int) bool  { return h[i].Dist < h[j].Dist }
+func (h *farthestMinHeap) Push(x interface{}) { *h = append(*h, x.(GeoRecord)) }
+func (h *farthestMinHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// pushFarthest adds record to h if h has fewer than k entries, or if
+// record is farther than h's current worst (root) entry.
+func pushFarthest(h *farthestMinHeap, record GeoRecord, k int) {
+	if h.Len() < k {
+		heap.Push(h, record)
+		return
+	}
+
+	if record.Dist > (*h)[0].Dist {
+		heap.Pop(h)
+		heap.Push(h, record)
+	}
+}
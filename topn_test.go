@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/influx6/geodisk/geo"
+	"github.com/influx6/geodisk/geodb"
+)
+
+// csvFixture builds an "id","lat","lng" csv of n rows around a small
+// grid of coordinates, cycling so every row is a valid lat/lng pair.
+func csvFixture(n int) string {
+	var buf strings.Builder
+	buf.WriteString("id,lat,lng\n")
+	for i := 0; i < n; i++ {
+		lat := float64(i%179) - 89
+		lng := float64(i%359) - 179
+		fmt.Fprintf(&buf, "row-%d,%f,%f\n", i, lat, lng)
+	}
+	return buf.String()
+}
+
+func TestTopN_KLargerThanInput(t *testing.T) {
+	fixture := csvFixture(3)
+
+	nearest, farthest, err := TopN(strings.NewReader(fixture), 0, 0, 10, 2)
+	if err != nil {
+		t.Fatalf("TopN returned error: %v", err)
+	}
+
+	if len(nearest) != 3 {
+		t.Fatalf("expected nearest to be capped at the 3 available rows, got %d", len(nearest))
+	}
+	if len(farthest) != 3 {
+		t.Fatalf("expected farthest to be capped at the 3 available rows, got %d", len(farthest))
+	}
+}
+
+func TestTopN_ZeroOrNegativeKDefaultsToFive(t *testing.T) {
+	fixture := csvFixture(20)
+
+	for _, k := range []int{0, -1, -5} {
+		nearest, farthest, err := TopN(strings.NewReader(fixture), 0, 0, k, 2)
+		if err != nil {
+			t.Fatalf("TopN(k=%d) returned error: %v", k, err)
+		}
+		if len(nearest) != 5 {
+			t.Errorf("TopN(k=%d): expected nearest to default to 5, got %d", k, len(nearest))
+		}
+		if len(farthest) != 5 {
+			t.Errorf("TopN(k=%d): expected farthest to default to 5, got %d", k, len(farthest))
+		}
+	}
+}
+
+func TestTopN_TiesAreAllCandidatesForEviction(t *testing.T) {
+	// Every row sits at the same distance from the target, so nearest
+	// and farthest must each still return exactly k of them rather
+	// than over- or under-filling the heap on a tie.
+	fixture := "id,lat,lng\n" +
+		"a,1.000000,1.000000\n" +
+		"b,-1.000000,-1.000000\n" +
+		"c,1.000000,-1.000000\n" +
+		"d,-1.000000,1.000000\n"
+
+	nearest, farthest, err := TopN(strings.NewReader(fixture), 0, 0, 2, 1)
+	if err != nil {
+		t.Fatalf("TopN returned error: %v", err)
+	}
+
+	if len(nearest) != 2 {
+		t.Fatalf("expected 2 nearest records on a tie, got %d", len(nearest))
+	}
+	if len(farthest) != 2 {
+		t.Fatalf("expected 2 farthest records on a tie, got %d", len(farthest))
+	}
+}
+
+func TestTopN_NearestAndFarthestOrdering(t *testing.T) {
+	fixture := "id,lat,lng\n" +
+		"close,0.000100,0.000100\n" +
+		"mid,1.000000,1.000000\n" +
+		"far,10.000000,10.000000\n"
+
+	nearest, farthest, err := TopN(strings.NewReader(fixture), 0, 0, 1, 1)
+	if err != nil {
+		t.Fatalf("TopN returned error: %v", err)
+	}
+
+	if len(nearest) != 1 || nearest[0].ID != "close" {
+		t.Fatalf("expected nearest=[close], got %+v", nearest)
+	}
+	if len(farthest) != 1 || farthest[0].ID != "far" {
+		t.Fatalf("expected farthest=[far], got %+v", farthest)
+	}
+}
+
+// fixtureReader streams an "id","lat","lng" csv of n rows without ever
+// materializing the whole thing in memory, so the benchmarks below can
+// run over a 10M-row fixture without a multi-hundred-MB allocation.
+type fixtureReader struct {
+	n           int
+	i           int
+	wroteHeader bool
+	buf         bytes.Buffer
+}
+
+func (f *fixtureReader) Read(p []byte) (int, error) {
+	for f.buf.Len() < len(p) {
+		if !f.wroteHeader {
+			f.buf.WriteString("id,lat,lng\n")
+			f.wroteHeader = true
+			continue
+		}
+		if f.i >= f.n {
+			break
+		}
+		fmt.Fprintf(&f.buf, "row-%d,%f,%f\n", f.i, float64(f.i%179)-89, float64(f.i%359)-179)
+		f.i++
+	}
+
+	if f.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+
+	return f.buf.Read(p)
+}
+
+// benchFixtureRows matches the 10M-row fixture size the benchmarks
+// below were asked to exercise.
+const benchFixtureRows = 10_000_000
+
+// naiveTopN is the pre-streaming approach TopN replaced: load every
+// row into memory, compute its distance, sort the whole slice and
+// slice off the head/tail. It exists only so BenchmarkNaiveTopN_10MRows
+// has something to compare TopN's bounded-heap streaming against.
+func naiveTopN(target io.Reader, targetLat, targetLong float64, k int) (nearest, farthest GeoRecords, err error) {
+	source, err := geodb.NewCSVSource(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer source.Close()
+
+	var records GeoRecords
+	for {
+		rec, rerr := source.Next()
+		if rerr != nil {
+			if rerr == io.EOF {
+				break
+			}
+			return nil, nil, rerr
+		}
+
+		var record GeoRecord
+		record.ID = rec.ID
+		record.Lat = geo.ToRadians(rec.Lat)
+		record.Long = geo.ToRadians(rec.Long)
+		record.Dist = geo.Haversin(record.Lat, record.Long, targetLat, targetLong)
+
+		records = append(records, record)
+	}
+
+	sort.Sort(records)
+
+	if k > len(records) {
+		k = len(records)
+	}
+
+	nearest = records[:k]
+
+	farthest = make(GeoRecords, k)
+	copy(farthest, records[len(records)-k:])
+	sort.Sort(sort.Reverse(farthest))
+
+	return nearest, farthest, nil
+}
+
+func BenchmarkTopN_10MRows(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _, err := TopN(&fixtureReader{n: benchFixtureRows}, 0, 0, 5, 4)
+		if err != nil {
+			b.Fatalf("TopN returned error: %v", err)
+		}
+	}
+}
+
+func BenchmarkNaiveTopN_10MRows(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _, err := naiveTopN(&fixtureReader{n: benchFixtureRows}, 0, 0, 5)
+		if err != nil {
+			b.Fatalf("naiveTopN returned error: %v", err)
+		}
+	}
+}